@@ -0,0 +1,107 @@
+package types
+
+import (
+	"reflect"
+	"time"
+)
+
+// columnData stores the values of a single Table column, one entry per row.
+// Unset cells are reported as nil by get. Values passed to set have already
+// been type checked by the Table.
+type columnData interface {
+	columnType() reflect.Type
+	get(index int) interface{}
+	set(index int, value interface{})
+	grow(value interface{})
+}
+
+// newColumn picks the storage for columnType. Common scalar types are stored
+// unboxed, anything else falls back to a slice of interface{}.
+func newColumn(columnType reflect.Type) columnData {
+	switch columnType {
+	case reflect.TypeFor[float64]():
+		return newTypedColumn[float64]()
+	case reflect.TypeFor[float32]():
+		return newTypedColumn[float32]()
+	case reflect.TypeFor[int]():
+		return newTypedColumn[int]()
+	case reflect.TypeFor[int64]():
+		return newTypedColumn[int64]()
+	case reflect.TypeFor[string]():
+		return newTypedColumn[string]()
+	case reflect.TypeFor[bool]():
+		return newTypedColumn[bool]()
+	case reflect.TypeFor[time.Time]():
+		return newTypedColumn[time.Time]()
+	}
+
+	return &anyColumn{
+		valueType: columnType,
+		values:    make([]interface{}, 0),
+	}
+}
+
+type typedColumn[V any] struct {
+	values []V
+	isSet  []bool
+}
+
+func newTypedColumn[V any]() *typedColumn[V] {
+	return &typedColumn[V]{
+		values: make([]V, 0),
+		isSet:  make([]bool, 0),
+	}
+}
+
+func (c *typedColumn[V]) columnType() reflect.Type {
+	return reflect.TypeFor[V]()
+}
+
+func (c *typedColumn[V]) get(index int) interface{} {
+	if !c.isSet[index] {
+		return nil
+	}
+	return c.values[index]
+}
+
+func (c *typedColumn[V]) set(index int, value interface{}) {
+	if value == nil {
+		var zero V
+		c.values[index] = zero
+		c.isSet[index] = false
+		return
+	}
+
+	// cannot fail: typed Table columns are named scalar types, which only
+	// accept values of exactly that type, and TimeseriesTable sets values as T
+	c.values[index] = value.(V)
+	c.isSet[index] = true
+}
+
+func (c *typedColumn[V]) grow(value interface{}) {
+	var zero V
+	c.values = append(c.values, zero)
+	c.isSet = append(c.isSet, false)
+	c.set(len(c.values)-1, value)
+}
+
+type anyColumn struct {
+	valueType reflect.Type // nil means the column is untyped
+	values    []interface{}
+}
+
+func (c *anyColumn) columnType() reflect.Type {
+	return c.valueType
+}
+
+func (c *anyColumn) get(index int) interface{} {
+	return c.values[index]
+}
+
+func (c *anyColumn) set(index int, value interface{}) {
+	c.values[index] = value
+}
+
+func (c *anyColumn) grow(value interface{}) {
+	c.values = append(c.values, value)
+}
@@ -1,31 +1,56 @@
 package types
 
 import (
+	"cmp"
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
 )
 
 type Row []interface{}
 
+// Table stores its values column by column, see columnData. Typed columns of
+// common scalar types keep their values unboxed.
 type Table struct {
 	columns   []string
 	columnMap map[string]int
-	rows      []Row
+	data      []columnData
+	numRows   int
 }
 
 func NewTable(columns []string) *Table {
 	columnMap := make(map[string]int, len(columns))
+	data := make([]columnData, len(columns))
 
 	for i, columnName := range columns {
 		columnMap[columnName] = i
+		data[i] = newColumn(nil)
 	}
 
 	return &Table{
-		columns:   columns,
+		columns:   append([]string(nil), columns...),
 		columnMap: columnMap,
-		rows:      make([]Row, 0),
+		data:      data,
+		numRows:   0,
 	}
 }
 
+// NewTypedTable creates a table whose columns only accept values assignable
+// to the matching entry of columnTypes. A nil entry leaves that column untyped.
+func NewTypedTable(columns []string, columnTypes []reflect.Type) (*Table, error) {
+	if len(columns) != len(columnTypes) {
+		return nil, fmt.Errorf("got %d column types for %d columns", len(columnTypes), len(columns))
+	}
+
+	t := NewTable(columns)
+	for i, columnType := range columnTypes {
+		t.data[i] = newColumn(columnType)
+	}
+	return t, nil
+}
+
 func (t *Table) AddColumn(newColumnName string, defaultValue interface{}) error {
 	if newColumnName == "" {
 		return fmt.Errorf("column name cannot be empty")
@@ -35,41 +60,100 @@ func (t *Table) AddColumn(newColumnName string, defaultValue interface{}) error
 		return fmt.Errorf("column %s already exists", newColumnName)
 	}
 
+	data := newColumn(nil)
+	for i := 0; i < t.numRows; i++ {
+		data.grow(defaultValue)
+	}
+
 	t.columns = append(t.columns, newColumnName)
 	t.columnMap[newColumnName] = len(t.columns) - 1
+	t.data = append(t.data, data)
+
+	return nil
+}
+
+// SetColumnType restricts column to values assignable to columnType, nil
+// makes it untyped again. Existing values must already satisfy the type.
+func (t *Table) SetColumnType(column string, columnType reflect.Type) error {
+	columnIndex, ok := t.columnMap[column]
+	if !ok {
+		return fmt.Errorf("column %s does not exist", column)
+	}
 
-	for i, row := range t.rows {
-		row = append(row, defaultValue)
-		t.rows[i] = row
+	previousColumn := t.data[columnIndex]
+	convertedColumn := newColumn(columnType)
+	t.data[columnIndex] = convertedColumn
+	for i := 0; i < t.numRows; i++ {
+		value := previousColumn.get(i)
+		if err := t.checkType(columnIndex, value); err != nil {
+			t.data[columnIndex] = previousColumn
+			return err
+		}
+		convertedColumn.grow(value)
 	}
 
 	return nil
 }
 
+func (t Table) ColumnType(column string) (reflect.Type, bool) {
+	columnIndex, ok := t.columnMap[column]
+	if !ok {
+		return nil, false
+	}
+
+	return t.data[columnIndex].columnType(), true
+}
+
 func (t Table) GetColumnValues(column string) ([]interface{}, bool) {
 	index, ok := t.columnMap[column]
 	if !ok {
 		return nil, false
 	}
 
-	values := make([]interface{}, t.NumRows())
-	for _, row := range t.rows {
-		value := row[index]
-		values = append(values, value)
+	values := make([]interface{}, t.numRows)
+	for i := range values {
+		values[i] = t.data[index].get(i)
+	}
+
+	return values, true
+}
+
+// ColumnValues returns a copy of column as a []V, without boxing when the
+// column is stored as V. Unset cells are zero values. It fails if the column
+// does not exist or holds values that are not V.
+func ColumnValues[V any](t *Table, column string) ([]V, bool) {
+	index, ok := t.columnMap[column]
+	if !ok {
+		return nil, false
+	}
+
+	if typed, ok := t.data[index].(*typedColumn[V]); ok {
+		return append([]V(nil), typed.values[:t.numRows]...), true
+	}
+
+	values := make([]V, t.numRows)
+	for i := range values {
+		value := t.data[index].get(i)
+		if value == nil {
+			continue
+		}
+		typedValue, ok := value.(V)
+		if !ok {
+			return nil, false
+		}
+		values[i] = typedValue
 	}
 
 	return values, true
 }
 
 func (t *Table) NewRow() int {
-	row := make(Row, len(t.columns))
-	for i := range t.columns {
-		row[i] = nil
+	for _, data := range t.data {
+		data.grow(nil)
 	}
 
-	t.rows = append(t.rows, row)
-	index := len(t.rows)
-	return index - 1
+	t.numRows++
+	return t.numRows - 1
 }
 
 func (t *Table) AddRow(row map[string]interface{}) (int, error) {
@@ -82,7 +166,7 @@ func (t *Table) AddRow(row map[string]interface{}) (int, error) {
 }
 
 func (t *Table) InsertRowAtIndex(index int, row map[string]interface{}) error {
-	if index < 0 || index >= len(t.rows) {
+	if index < 0 || index >= t.numRows {
 		return fmt.Errorf("index %d out of range", index)
 	}
 
@@ -97,7 +181,7 @@ func (t *Table) InsertRowAtIndex(index int, row map[string]interface{}) error {
 }
 
 func (t Table) GetRow(index int) (map[string]interface{}, bool) {
-	if index < 0 || index >= len(t.rows) {
+	if index < 0 || index >= t.numRows {
 		return nil, false
 	}
 
@@ -109,60 +193,248 @@ func (t *Table) SetRow(index int, row map[string]interface{}) error {
 }
 
 func (t Table) GetValueByIndex(index int, column string) (interface{}, bool) {
-	if index < 0 || index >= len(t.rows) {
+	if index < 0 || index >= t.numRows {
 		return nil, false
 	}
 
 	if columnIndex, ok := t.columnMap[column]; ok {
-		return t.rows[index][columnIndex], true
+		return t.data[columnIndex].get(index), true
 	}
 
 	return nil, false
 }
 
 func (t *Table) SetValueByIndex(index int, column string, value interface{}) error {
-	if _, ok := t.columnMap[column]; !ok {
+	columnIndex, ok := t.columnMap[column]
+	if !ok {
 		return fmt.Errorf("column %s does not exist", column)
 	}
 
-	if index < 0 || index >= len(t.rows) {
+	if index < 0 || index >= t.numRows {
 		return fmt.Errorf("row by index %d does not exist", index)
 	}
 
-	t.rows[index][t.columnMap[column]] = value
+	if err := t.checkType(columnIndex, value); err != nil {
+		return err
+	}
+
+	t.data[columnIndex].set(index, value)
 	return nil
 }
 
 func (t *Table) Iterator() <-chan Row {
 	ch := make(chan Row)
 	go func() {
-		for _, row := range t.rows {
-			ch <- row
+		for i := 0; i < t.numRows; i++ {
+			ch <- t.row(i)
 		}
 		close(ch)
 	}()
 	return ch
 }
 
+// Head returns a copy of the first n rows, or of the first 5 if n <= 0. The
+// copy never shares storage with t.
 func (t Table) Head(n int) Table {
-	if n >= len(t.rows) {
-		return t
-	}
-
 	if n <= 0 {
 		n = 5
 	}
 
-	newTable := NewTable(t.columns)
+	if n > t.numRows {
+		n = t.numRows
+	}
+
+	newTable := t.emptyCopy(t.columns)
 	for i := 0; i < n; i++ {
-		row, _ := t.GetRow(i)
-		newTable.AddRow(row)
+		newTable.copyRowFrom(t, i)
 	}
 
 	return *newTable
 }
 
+// Filter returns a new table with the rows for which predicate returns true.
+func (t Table) Filter(predicate func(row map[string]interface{}) bool) *Table {
+	newTable := t.emptyCopy(t.columns)
+	for i := 0; i < t.numRows; i++ {
+		if predicate(t.convertRow(i)) {
+			newTable.copyRowFrom(t, i)
+		}
+	}
+
+	return newTable
+}
+
+// Select returns a new table with only the given columns, in that order.
+func (t Table) Select(columns ...string) (*Table, error) {
+	selected := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if _, ok := t.columnMap[column]; !ok {
+			return nil, fmt.Errorf("column %s does not exist", column)
+		}
+		if selected[column] {
+			return nil, fmt.Errorf("column %s selected more than once", column)
+		}
+		selected[column] = true
+	}
+
+	newTable := t.emptyCopy(columns)
+	for i := 0; i < t.numRows; i++ {
+		newTable.copyRowFrom(t, i)
+	}
+
+	return newTable, nil
+}
+
+// SortBy returns a new table with the rows stably sorted by column in
+// ascending order. Unset and NaN values sort last, in their original order.
+func (t Table) SortBy(column string) (*Table, error) {
+	columnIndex, ok := t.columnMap[column]
+	if !ok {
+		return nil, fmt.Errorf("column %s does not exist", column)
+	}
+
+	order := make([]int, t.numRows)
+	for i := range order {
+		order[i] = i
+	}
+
+	var err error
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := t.data[columnIndex].get(order[i]), t.data[columnIndex].get(order[j])
+		if isMissing(a) || isMissing(b) {
+			return !isMissing(a) && isMissing(b)
+		}
+
+		result, compareErr := compareValues(a, b)
+		if compareErr != nil && err == nil {
+			err = fmt.Errorf("cannot sort by column %s: %w", column, compareErr)
+		}
+		return result < 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newTable := t.emptyCopy(t.columns)
+	for _, index := range order {
+		newTable.copyRowFrom(t, index)
+	}
+
+	return newTable, nil
+}
+
+// Map returns a new table with the same columns where each row is replaced by
+// the result of fn. Columns missing from the result are left unset.
+func (t Table) Map(fn func(row map[string]interface{}) map[string]interface{}) (*Table, error) {
+	newTable := t.emptyCopy(t.columns)
+	for i := 0; i < t.numRows; i++ {
+		_, err := newTable.AddRow(fn(t.convertRow(i)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newTable, nil
+}
+
 /* HELPER FUNCTIONS */
+// emptyCopy creates a table with the given columns of t and the same column
+// types, but no rows.
+func (t Table) emptyCopy(columns []string) *Table {
+	newTable := NewTable(columns)
+	for i, column := range columns {
+		newTable.data[i] = newColumn(t.data[t.columnMap[column]].columnType())
+	}
+	return newTable
+}
+
+// copyRowFrom appends row index of src. Values are not type checked again, so
+// every column of t must exist in src with the same type.
+func (t *Table) copyRowFrom(src Table, index int) {
+	for i, column := range t.columns {
+		t.data[i].grow(src.data[src.columnMap[column]].get(index))
+	}
+	t.numRows++
+}
+
+// checkType reports whether value can be stored in the column, nil always can.
+func (t Table) checkType(columnIndex int, value interface{}) error {
+	columnType := t.data[columnIndex].columnType()
+	if columnType == nil || value == nil {
+		return nil
+	}
+
+	if !reflect.TypeOf(value).AssignableTo(columnType) {
+		return fmt.Errorf("column %s expects %s, got %T", t.columns[columnIndex], columnType, value)
+	}
+
+	return nil
+}
+
+// compareValues orders two values of the same kind: numbers, strings, bools
+// and time.Time. Numbers of different kinds are compared as float64.
+func compareValues(a, b interface{}) (int, error) {
+	if aTime, ok := a.(time.Time); ok {
+		if bTime, ok := b.(time.Time); ok {
+			return aTime.Compare(bTime), nil
+		}
+		return 0, fmt.Errorf("cannot compare %T and %T", a, b)
+	}
+
+	aValue, bValue := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case aValue.CanInt() && bValue.CanInt():
+		return cmp.Compare(aValue.Int(), bValue.Int()), nil
+	case aValue.CanUint() && bValue.CanUint():
+		return cmp.Compare(aValue.Uint(), bValue.Uint()), nil
+	case isNumber(aValue) && isNumber(bValue):
+		return cmp.Compare(toFloat(aValue), toFloat(bValue)), nil
+	case aValue.Kind() == reflect.String && bValue.Kind() == reflect.String:
+		return cmp.Compare(aValue.String(), bValue.String()), nil
+	case aValue.Kind() == reflect.Bool && bValue.Kind() == reflect.Bool:
+		if aValue.Bool() == bValue.Bool() {
+			return 0, nil
+		} else if bValue.Bool() {
+			return -1, nil
+		}
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("cannot compare %T and %T", a, b)
+}
+
+// isMissing reports whether value is unset or a NaN float.
+func isMissing(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	reflectValue := reflect.ValueOf(value)
+	return reflectValue.CanFloat() && math.IsNaN(reflectValue.Float())
+}
+
+func isNumber(value reflect.Value) bool {
+	return value.CanInt() || value.CanUint() || value.CanFloat()
+}
+
+func toFloat(value reflect.Value) float64 {
+	switch {
+	case value.CanInt():
+		return float64(value.Int())
+	case value.CanUint():
+		return float64(value.Uint())
+	}
+	return value.Float()
+}
+
+func (t Table) row(index int) Row {
+	row := make(Row, len(t.data))
+	for i, data := range t.data {
+		row[i] = data.get(index)
+	}
+	return row
+}
+
 func (t Table) convertRow(index int) map[string]interface{} {
 	result := make(map[string]interface{})
 	for _, columnName := range t.columns {
@@ -173,7 +445,7 @@ func (t Table) convertRow(index int) map[string]interface{} {
 }
 
 func (t Table) NumRows() int {
-	return len(t.rows)
+	return t.numRows
 }
 
 func (t Table) NumCols() int {
@@ -184,8 +456,14 @@ func (t Table) Cols() []string {
 	return t.columns
 }
 
+// Rows builds a row-major copy of the table. Changing the returned rows does
+// not change the table, use Set for that.
 func (t Table) Rows() []Row {
-	return t.rows
+	rows := make([]Row, t.numRows)
+	for i := range rows {
+		rows[i] = t.row(i)
+	}
+	return rows
 }
 
 func (t Table) Get(index int, column string) (interface{}, bool) {
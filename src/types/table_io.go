@@ -0,0 +1,551 @@
+package types
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column types that can be written and read back by the CSV and JSON helpers.
+// Untyped columns can only be written while they hold strings, anything else
+// would not read back as the same value; set the column's type first.
+var serializableTypes = map[string]reflect.Type{
+	"float64":   reflect.TypeFor[float64](),
+	"float32":   reflect.TypeFor[float32](),
+	"int":       reflect.TypeFor[int](),
+	"int64":     reflect.TypeFor[int64](),
+	"string":    reflect.TypeFor[string](),
+	"bool":      reflect.TypeFor[bool](),
+	"time.Time": reflect.TypeFor[time.Time](),
+}
+
+const timestampColumn = "timestamp"
+
+// csvNull marks an unset cell in CSV, so that an empty field can hold an
+// empty string. String values starting with a backslash are escaped with a
+// second backslash so they are never mistaken for it.
+const csvNull = `\N`
+
+type jsonColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+type jsonTable struct {
+	Columns []jsonColumn        `json:"columns"`
+	Rows    [][]json.RawMessage `json:"rows"`
+}
+
+type jsonTimeseriesTable struct {
+	Columns    []jsonColumn        `json:"columns"`
+	Timestamps []time.Time         `json:"timestamps"`
+	Rows       [][]json.RawMessage `json:"rows"`
+}
+
+// ToCSV writes the table with a header of column names. Typed columns are
+// written as "name:type" so TableFromCSV can restore them, column names can
+// therefore not contain ":". Unset cells are written as csvNull.
+func (t Table) ToCSV(w io.Writer) error {
+	header, err := t.schemaHeader()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(t.columns))
+	for i := 0; i < t.numRows; i++ {
+		for j := range t.data {
+			value, err := t.exportValue(i, j)
+			if err != nil {
+				return err
+			}
+			record[j] = formatCSVValue(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// TableFromCSV reads a table written by ToCSV. Empty fields are empty strings
+// in string columns and unset cells in any other column.
+func TableFromCSV(r io.Reader) (*Table, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading csv header: %w", err)
+	}
+
+	columns, columnTypes := parseSchemaHeader(header)
+	t, err := NewTypedTable(columns, columnTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		index := t.NewRow()
+		for i, field := range record {
+			value, err := parseCSVValue(field, columnTypes[i])
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %s: %w", index, columns[i], err)
+			}
+			t.data[i].set(index, value)
+		}
+	}
+
+	return t, nil
+}
+
+// ToJSON writes the table as {"columns": [...], "rows": [[...], ...]}. Unset
+// cells are written as null, NaN and infinite floats as "NaN", "+Inf" and
+// "-Inf" since JSON has no numbers for them.
+func (t Table) ToJSON(w io.Writer) error {
+	columns, err := t.schemaColumns()
+	if err != nil {
+		return err
+	}
+
+	table := jsonTable{
+		Columns: columns,
+		Rows:    make([][]json.RawMessage, t.numRows),
+	}
+	for i := range table.Rows {
+		row := make([]json.RawMessage, len(t.data))
+		for j := range t.data {
+			value, err := t.exportValue(i, j)
+			if err != nil {
+				return err
+			}
+			row[j], err = marshalJSONValue(value)
+			if err != nil {
+				return fmt.Errorf("row %d, column %s: %w", i, t.columns[j], err)
+			}
+		}
+		table.Rows[i] = row
+	}
+
+	return json.NewEncoder(w).Encode(table)
+}
+
+// TableFromJSON reads a table written by ToJSON.
+func TableFromJSON(r io.Reader) (*Table, error) {
+	var table jsonTable
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(table.Columns))
+	columnTypes := make([]reflect.Type, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = column.Name
+		if column.Type != "" {
+			columnType, ok := serializableTypes[column.Type]
+			if !ok {
+				return nil, fmt.Errorf("column %s has unsupported type %s", column.Name, column.Type)
+			}
+			columnTypes[i] = columnType
+		}
+	}
+
+	t, err := NewTypedTable(columns, columnTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range table.Rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("row %d has %d values for %d columns", t.numRows, len(row), len(columns))
+		}
+
+		index := t.NewRow()
+		for i, raw := range row {
+			value, err := parseJSONValue(raw, columnTypes[i])
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %s: %w", index, columns[i], err)
+			}
+			t.data[i].set(index, value)
+		}
+	}
+
+	return t, nil
+}
+
+// ToCSV writes a "timestamp" column followed by the table's columns, in
+// timestamp order. T must be one of the scalar column types.
+func (t *TimeseriesTable[T]) ToCSV(w io.Writer) error {
+	typeName, err := timeseriesTypeName[T]()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{timestampColumn}
+	for _, column := range t.Cols() {
+		if err := checkCSVColumnName(column); err != nil {
+			return err
+		}
+		header = append(header, column+":"+typeName)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	t.sortTimestamps()
+	for _, timestamp := range t.timestampArr {
+		index, _ := t.GetIndexFor(timestamp)
+		record := []string{timestamp.Format(time.RFC3339Nano)}
+		for _, data := range t.table.data {
+			record = append(record, formatCSVValue(data.get(index)))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// TimeseriesTableFromCSV reads a table written by TimeseriesTable.ToCSV.
+func TimeseriesTableFromCSV[T any](r io.Reader) (*TimeseriesTable[T], error) {
+	typeName, err := timeseriesTypeName[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading csv header: %w", err)
+	}
+	if len(header) == 0 || header[0] != timestampColumn {
+		return nil, fmt.Errorf("first csv column must be %s", timestampColumn)
+	}
+
+	columns, columnTypes := parseSchemaHeader(header[1:])
+	for i, columnType := range columnTypes {
+		if columnType != nil && columnType != reflect.TypeFor[T]() {
+			return nil, fmt.Errorf("column %s has type %s, expected %s", columns[i], columnType, typeName)
+		}
+	}
+
+	t := NewTimeseriesTable[T](columns)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		timestamp, err := time.Parse(time.RFC3339Nano, record[0])
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]T)
+		for i, field := range record[1:] {
+			value, err := parseCSVValue(field, reflect.TypeFor[T]())
+			if err != nil {
+				return nil, fmt.Errorf("timestamp %s, column %s: %w", record[0], columns[i], err)
+			}
+			if value != nil {
+				row[columns[i]] = value.(T)
+			}
+		}
+
+		if err := t.AddRow(timestamp, row); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// ToJSON writes the table as parallel "timestamps" and "rows" arrays in
+// timestamp order. Values are encoded with encoding/json, so any T that
+// round-trips through JSON is supported. Float T values that are NaN or
+// infinite are written as strings like Table.ToJSON does, floats nested in
+// other types are left to encoding/json and cannot be NaN or infinite.
+func (t *TimeseriesTable[T]) ToJSON(w io.Writer) error {
+	t.sortTimestamps()
+	typeName := reflect.TypeFor[T]().String()
+	table := jsonTimeseriesTable{
+		Columns:    make([]jsonColumn, 0, len(t.Cols())),
+		Timestamps: t.timestampArr,
+		Rows:       make([][]json.RawMessage, len(t.timestampArr)),
+	}
+	for _, column := range t.Cols() {
+		table.Columns = append(table.Columns, jsonColumn{Name: column, Type: typeName})
+	}
+
+	for i, timestamp := range t.timestampArr {
+		values, _ := t.GetRow(timestamp)
+		row := make([]json.RawMessage, len(t.Cols()))
+		for j, column := range t.Cols() {
+			var value interface{}
+			if typedValue, ok := values[column]; ok {
+				value = typedValue
+			}
+
+			var err error
+			row[j], err = marshalJSONValue(value)
+			if err != nil {
+				return fmt.Errorf("timestamp %s, column %s: %w", timestamp, column, err)
+			}
+		}
+		table.Rows[i] = row
+	}
+
+	return json.NewEncoder(w).Encode(table)
+}
+
+// TimeseriesTableFromJSON reads a table written by TimeseriesTable.ToJSON.
+func TimeseriesTableFromJSON[T any](r io.Reader) (*TimeseriesTable[T], error) {
+	var table jsonTimeseriesTable
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return nil, err
+	}
+
+	if len(table.Timestamps) != len(table.Rows) {
+		return nil, fmt.Errorf("got %d timestamps for %d rows", len(table.Timestamps), len(table.Rows))
+	}
+
+	typeName := reflect.TypeFor[T]().String()
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		if column.Type != "" && column.Type != typeName {
+			return nil, fmt.Errorf("column %s has type %s, expected %s", column.Name, column.Type, typeName)
+		}
+		columns[i] = column.Name
+	}
+
+	t := NewTimeseriesTable[T](columns)
+	for i, values := range table.Rows {
+		if len(values) != len(columns) {
+			return nil, fmt.Errorf("row %d has %d values for %d columns", i, len(values), len(columns))
+		}
+
+		row := make(map[string]T)
+		for j, raw := range values {
+			value, err := parseJSONValue(raw, reflect.TypeFor[T]())
+			if err != nil {
+				return nil, fmt.Errorf("timestamp %s, column %s: %w", table.Timestamps[i], columns[j], err)
+			}
+			if value != nil {
+				row[columns[j]] = value.(T)
+			}
+		}
+
+		if err := t.AddRow(table.Timestamps[i], row); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+/* HELPER FUNCTIONS */
+func (t Table) schemaColumns() ([]jsonColumn, error) {
+	columns := make([]jsonColumn, len(t.columns))
+	for i, data := range t.data {
+		columns[i].Name = t.columns[i]
+		if columnType := data.columnType(); columnType != nil {
+			if _, ok := serializableTypes[columnType.String()]; !ok {
+				return nil, fmt.Errorf("column %s has unsupported type %s", t.columns[i], columnType)
+			}
+			columns[i].Type = columnType.String()
+		}
+	}
+	return columns, nil
+}
+
+func (t Table) schemaHeader() ([]string, error) {
+	columns, err := t.schemaColumns()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		if err := checkCSVColumnName(column.Name); err != nil {
+			return nil, err
+		}
+		header[i] = column.Name
+		if column.Type != "" {
+			header[i] += ":" + column.Type
+		}
+	}
+	return header, nil
+}
+
+// exportValue returns the value at row index of column columnIndex, failing for
+// untyped columns holding anything but strings.
+func (t Table) exportValue(index, columnIndex int) (interface{}, error) {
+	value := t.data[columnIndex].get(index)
+	if t.data[columnIndex].columnType() == nil && value != nil {
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("column %s is untyped and holds %T at row %d, set its type before exporting", t.columns[columnIndex], value, index)
+		}
+	}
+	return value, nil
+}
+
+// checkCSVColumnName rejects names that parseSchemaHeader would misread.
+func checkCSVColumnName(column string) error {
+	if strings.Contains(column, ":") {
+		return fmt.Errorf("column %s cannot be written to csv, names cannot contain \":\"", column)
+	}
+	return nil
+}
+
+// parseSchemaHeader splits "name:type" header fields. Fields without a known
+// type suffix are untyped columns named by the whole field.
+func parseSchemaHeader(header []string) ([]string, []reflect.Type) {
+	columns := make([]string, len(header))
+	columnTypes := make([]reflect.Type, len(header))
+	for i, field := range header {
+		columns[i] = field
+		if separator := strings.LastIndex(field, ":"); separator >= 0 {
+			if columnType, ok := serializableTypes[field[separator+1:]]; ok {
+				columns[i] = field[:separator]
+				columnTypes[i] = columnType
+			}
+		}
+	}
+	return columns, columnTypes
+}
+
+func timeseriesTypeName[T any]() (string, error) {
+	typeName := reflect.TypeFor[T]().String()
+	if _, ok := serializableTypes[typeName]; !ok {
+		return "", fmt.Errorf("csv does not support values of type %s", typeName)
+	}
+	return typeName, nil
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return csvNull
+	case string:
+		if strings.HasPrefix(v, `\`) {
+			return `\` + v
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+func parseCSVValue(field string, columnType reflect.Type) (interface{}, error) {
+	if field == csvNull {
+		return nil, nil
+	}
+
+	if columnType == nil || columnType == reflect.TypeFor[string]() {
+		return strings.TrimPrefix(field, `\`), nil
+	}
+
+	if field == "" {
+		return nil, nil
+	}
+
+	switch columnType {
+	case reflect.TypeFor[float64]():
+		return strconv.ParseFloat(field, 64)
+	case reflect.TypeFor[float32]():
+		value, err := strconv.ParseFloat(field, 32)
+		return float32(value), err
+	case reflect.TypeFor[int]():
+		return strconv.Atoi(field)
+	case reflect.TypeFor[int64]():
+		return strconv.ParseInt(field, 10, 64)
+	case reflect.TypeFor[bool]():
+		return strconv.ParseBool(field)
+	case reflect.TypeFor[time.Time]():
+		return time.Parse(time.RFC3339Nano, field)
+	}
+
+	return nil, fmt.Errorf("unsupported column type %s", columnType)
+}
+
+// marshalJSONValue encodes value, writing NaN and infinite floats as strings.
+func marshalJSONValue(value interface{}) (json.RawMessage, error) {
+	if value != nil {
+		if number := reflect.ValueOf(value); number.CanFloat() {
+			if f := number.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+				return json.Marshal(strconv.FormatFloat(f, 'g', -1, 64))
+			}
+		}
+	}
+
+	return json.Marshal(value)
+}
+
+func parseJSONValue(raw json.RawMessage, columnType reflect.Type) (interface{}, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	// NaN and infinite floats are written as strings by marshalJSONValue
+	if columnType != nil && isFloatType(columnType) && len(raw) > 0 && raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(text, columnType.Bits())
+		if err != nil {
+			return nil, err
+		}
+		value := reflect.New(columnType).Elem()
+		value.SetFloat(f)
+		return value.Interface(), nil
+	}
+
+	// untyped columns are only written while they hold strings
+	if columnType == nil {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("untyped column holds %s, expected a string", raw)
+		}
+		return value, nil
+	}
+
+	value := reflect.New(columnType)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+func isFloatType(columnType reflect.Type) bool {
+	return columnType.Kind() == reflect.Float32 || columnType.Kind() == reflect.Float64
+}
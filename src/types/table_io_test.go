@@ -0,0 +1,276 @@
+package types
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// sameValue compares cell values, treating NaN as equal to NaN and times by
+// instant.
+func sameValue(a, b interface{}) bool {
+	if aTime, ok := a.(time.Time); ok {
+		bTime, ok := b.(time.Time)
+		return ok && aTime.Equal(bTime)
+	}
+	if aFloat, ok := a.(float64); ok && math.IsNaN(aFloat) {
+		bFloat, ok := b.(float64)
+		return ok && math.IsNaN(bFloat)
+	}
+	return a == b
+}
+
+func assertTablesEqual(t *testing.T, got, want *Table) {
+	t.Helper()
+	if !reflect.DeepEqual(got.Cols(), want.Cols()) {
+		t.Fatalf("columns = %v, want %v", got.Cols(), want.Cols())
+	}
+	if got.NumRows() != want.NumRows() {
+		t.Fatalf("got %d rows, want %d", got.NumRows(), want.NumRows())
+	}
+	for _, column := range want.Cols() {
+		gotType, _ := got.ColumnType(column)
+		wantType, _ := want.ColumnType(column)
+		if gotType != wantType {
+			t.Errorf("column %s type = %v, want %v", column, gotType, wantType)
+		}
+		for i := 0; i < want.NumRows(); i++ {
+			gotValue, _ := got.Get(i, column)
+			wantValue, _ := want.Get(i, column)
+			if !sameValue(gotValue, wantValue) {
+				t.Errorf("row %d column %s = %#v, want %#v", i, column, gotValue, wantValue)
+			}
+		}
+	}
+}
+
+func TestTableJSONNonFiniteFloats(t *testing.T) {
+	table, _ := NewTypedTable([]string{"f", "g"}, []reflect.Type{reflect.TypeFor[float64](), reflect.TypeFor[float32]()})
+	table.AddRow(map[string]interface{}{"f": math.NaN(), "g": float32(math.Inf(-1))})
+	table.AddRow(map[string]interface{}{"f": math.Inf(1), "g": float32(1.5)})
+	table.AddRow(map[string]interface{}{"f": math.Inf(-1)})
+
+	var buf bytes.Buffer
+	if err := table.ToJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := TableFromJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTablesEqual(t, back, table)
+}
+
+func TestTimeseriesTableJSONNonFiniteFloats(t *testing.T) {
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	table := NewTimeseriesTable[float64]([]string{"close", "sma_20"})
+	table.AddRow(start, map[string]float64{"close": 100, "sma_20": math.NaN()})
+	table.AddRow(start.Add(time.Minute), map[string]float64{"close": math.Inf(1)})
+
+	var buf bytes.Buffer
+	if err := table.ToJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := TimeseriesTableFromJSON[float64](&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok := back.GetValue(start, "sma_20"); !ok || !math.IsNaN(value) {
+		t.Errorf("sma_20 = %v, %v, want NaN", value, ok)
+	}
+	if value, ok := back.GetValue(start.Add(time.Minute), "close"); !ok || !math.IsInf(value, 1) {
+		t.Errorf("close = %v, %v, want +Inf", value, ok)
+	}
+	if _, ok := back.GetValue(start.Add(time.Minute), "sma_20"); ok {
+		t.Error("unset sma_20 should stay unset")
+	}
+}
+
+func TestTableCSVEmptyAndUnsetStrings(t *testing.T) {
+	table, _ := NewTypedTable([]string{"s", "f"}, []reflect.Type{reflect.TypeFor[string](), reflect.TypeFor[float64]()})
+	for _, value := range []interface{}{"", nil, `\N`, `\\path`, "a,\"b\"\nc"} {
+		table.AddRow(map[string]interface{}{"s": value, "f": 1.5})
+	}
+	table.AddRow(map[string]interface{}{"s": "x"})
+
+	var buf bytes.Buffer
+	if err := table.ToCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := TableFromCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTablesEqual(t, back, table)
+}
+
+func TestTimeseriesTableCSVEmptyString(t *testing.T) {
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	table := NewTimeseriesTable[string]([]string{"signal", "note"})
+	table.AddRow(start, map[string]string{"signal": ""})
+
+	var buf bytes.Buffer
+	if err := table.ToCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := TimeseriesTableFromCSV[string](&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := back.GetValue(start, "signal"); !ok || value != "" {
+		t.Errorf("signal = %q, %v, want empty string", value, ok)
+	}
+	if _, ok := back.GetValue(start, "note"); ok {
+		t.Error("unset note should stay unset")
+	}
+}
+
+func TestCSVRejectsColonInColumnName(t *testing.T) {
+	table := NewTable([]string{"ratio:int"})
+	table.AddRow(map[string]interface{}{"ratio:int": "abc"})
+	var buf bytes.Buffer
+	if err := table.ToCSV(&buf); err == nil {
+		t.Error("expected an error for a column name containing ':'")
+	}
+
+	timeseries := NewTimeseriesTable[float64]([]string{"a:b"})
+	if err := timeseries.ToCSV(&buf); err == nil {
+		t.Error("expected an error for a timeseries column name containing ':'")
+	}
+}
+
+func newScalarTable(t *testing.T) *Table {
+	t.Helper()
+	columns := []string{"f64", "f32", "i", "i64", "s", "b", "t", "u"}
+	columnTypes := []reflect.Type{
+		reflect.TypeFor[float64](),
+		reflect.TypeFor[float32](),
+		reflect.TypeFor[int](),
+		reflect.TypeFor[int64](),
+		reflect.TypeFor[string](),
+		reflect.TypeFor[bool](),
+		reflect.TypeFor[time.Time](),
+		nil,
+	}
+	table, err := NewTypedTable(columns, columnTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table.AddRow(map[string]interface{}{
+		"f64": 0.1,
+		"f32": float32(2.5),
+		"i":   -7,
+		"i64": int64(1) << 40,
+		"s":   "AAPL",
+		"b":   true,
+		"t":   time.Date(2024, 3, 10, 1, 2, 3, 456, time.FixedZone("", -5*3600)),
+		"u":   "untyped",
+	})
+	table.AddRow(map[string]interface{}{"i": 3})
+	return table
+}
+
+func TestTableRoundTrip(t *testing.T) {
+	formats := []struct {
+		name  string
+		write func(*Table, *bytes.Buffer) error
+		read  func(*bytes.Buffer) (*Table, error)
+	}{
+		{
+			"csv",
+			func(table *Table, buf *bytes.Buffer) error { return table.ToCSV(buf) },
+			func(buf *bytes.Buffer) (*Table, error) { return TableFromCSV(buf) },
+		},
+		{
+			"json",
+			func(table *Table, buf *bytes.Buffer) error { return table.ToJSON(buf) },
+			func(buf *bytes.Buffer) (*Table, error) { return TableFromJSON(buf) },
+		},
+	}
+
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			table := newScalarTable(t)
+			var buf bytes.Buffer
+			if err := format.write(table, &buf); err != nil {
+				t.Fatal(err)
+			}
+			back, err := format.read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTablesEqual(t, back, table)
+		})
+	}
+}
+
+func TestExportRejectsUntypedNonStrings(t *testing.T) {
+	table := NewTable([]string{"u"})
+	table.AddRow(map[string]interface{}{"u": 42})
+
+	var buf bytes.Buffer
+	if err := table.ToCSV(&buf); err == nil {
+		t.Error("expected ToCSV to fail on an untyped int")
+	}
+	if err := table.ToJSON(&buf); err == nil {
+		t.Error("expected ToJSON to fail on an untyped int")
+	}
+
+	if err := table.SetColumnType("u", reflect.TypeFor[int]()); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := table.ToJSON(&buf); err != nil {
+		t.Fatalf("typed column should export: %v", err)
+	}
+}
+
+func TestTableFromJSONUntypedNonString(t *testing.T) {
+	input := `{"columns":[{"name":"u"}],"rows":[[1]]}`
+	if _, err := TableFromJSON(bytes.NewBufferString(input)); err == nil {
+		t.Error("expected an error for a number in an untyped column")
+	}
+}
+
+func TestTimeseriesTableJSONStructs(t *testing.T) {
+	type candle struct {
+		Open, Close float64
+	}
+
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	table := NewTimeseriesTable[candle]([]string{"AAPL", "MSFT"})
+	table.AddRow(start.Add(time.Minute), map[string]candle{"AAPL": {1, 2}})
+	table.AddRow(start, map[string]candle{"AAPL": {3, 4}, "MSFT": {5, 6}})
+
+	var buf bytes.Buffer
+	if err := table.ToCSV(&buf); err == nil {
+		t.Error("expected ToCSV to fail for struct values")
+	}
+
+	buf.Reset()
+	if err := table.ToJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.String()
+	back, err := TimeseriesTableFromJSON[candle](&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.NumRows() != 2 {
+		t.Fatalf("got %d rows, want 2", back.NumRows())
+	}
+	if value, _ := back.GetValue(start, "MSFT"); value != (candle{5, 6}) {
+		t.Errorf("MSFT = %v, want {5 6}", value)
+	}
+	if _, ok := back.GetValue(start.Add(time.Minute), "MSFT"); ok {
+		t.Error("unset MSFT should stay unset")
+	}
+
+	if _, err := TimeseriesTableFromJSON[float64](bytes.NewBufferString(encoded)); err == nil {
+		t.Error("expected an error reading candle columns as float64")
+	}
+}
@@ -0,0 +1,303 @@
+package types
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func newPriceTable(t *testing.T, prices ...float64) *Table {
+	t.Helper()
+	table, err := NewTypedTable([]string{"f", "s"}, []reflect.Type{reflect.TypeFor[float64](), nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, price := range prices {
+		if _, err := table.AddRow(map[string]interface{}{"f": price}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return table
+}
+
+func TestHeadDoesNotShareStorage(t *testing.T) {
+	table := newPriceTable(t, 1)
+
+	head := table.Head(3)
+	if head.NumRows() != 1 {
+		t.Fatalf("head has %d rows, want 1", head.NumRows())
+	}
+	if _, err := head.AddRow(map[string]interface{}{"f": 5.0}); err != nil {
+		t.Fatal(err)
+	}
+
+	values, ok := ColumnValues[float64](table, "f")
+	if !ok || !reflect.DeepEqual(values, []float64{1}) {
+		t.Fatalf("original column = %v, want [1]", values)
+	}
+
+	index := table.NewRow()
+	if value, _ := table.Get(index, "f"); value != nil {
+		t.Fatalf("new row in original has f = %v, want unset", value)
+	}
+}
+
+func TestHeadRowCount(t *testing.T) {
+	table := newPriceTable(t, 1, 2, 3, 4, 5, 6, 7)
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{0, 5},
+		{2, 2},
+		{10, 7},
+	}
+	for _, test := range tests {
+		if got := table.Head(test.n).NumRows(); got != test.want {
+			t.Errorf("Head(%d) has %d rows, want %d", test.n, got, test.want)
+		}
+	}
+
+	if got := newPriceTable(t, 1, 2).Head(0).NumRows(); got != 2 {
+		t.Errorf("Head(0) of 2 rows has %d rows, want 2", got)
+	}
+}
+
+func TestColumnValues(t *testing.T) {
+	table := newPriceTable(t, 1, 2)
+	table.NewRow()
+
+	values, ok := ColumnValues[float64](table, "f")
+	if !ok || !reflect.DeepEqual(values, []float64{1, 2, 0}) {
+		t.Fatalf("ColumnValues = %v, %v", values, ok)
+	}
+
+	values[0] = 42
+	if value, _ := table.Get(0, "f"); value != 1.0 {
+		t.Fatalf("changing the returned slice changed the table: %v", value)
+	}
+
+	if _, ok := ColumnValues[int](table, "f"); ok {
+		t.Error("expected ColumnValues[int] on a float64 column to fail")
+	}
+	if _, ok := ColumnValues[float64](table, "missing"); ok {
+		t.Error("expected ColumnValues on a missing column to fail")
+	}
+}
+
+func TestSetRejectsNamedScalarTypes(t *testing.T) {
+	type price float64
+
+	table := newPriceTable(t, 1)
+	if err := table.Set(0, "f", price(2)); err == nil {
+		t.Error("expected an error setting a named float type on a float64 column")
+	}
+	if value, _ := table.Get(0, "f"); value != 1.0 {
+		t.Errorf("f = %v after a rejected set, want 1", value)
+	}
+}
+
+func TestNewTypedTable(t *testing.T) {
+	if _, err := NewTypedTable([]string{"a", "b"}, []reflect.Type{reflect.TypeFor[int]()}); err == nil {
+		t.Error("expected an error for mismatched column and type counts")
+	}
+
+	table := newPriceTable(t)
+	if columnType, ok := table.ColumnType("f"); !ok || columnType != reflect.TypeFor[float64]() {
+		t.Errorf("ColumnType(f) = %v, %v, want float64", columnType, ok)
+	}
+	if columnType, ok := table.ColumnType("s"); !ok || columnType != nil {
+		t.Errorf("ColumnType(s) = %v, %v, want untyped", columnType, ok)
+	}
+	if _, ok := table.ColumnType("missing"); ok {
+		t.Error("expected ColumnType on a missing column to fail")
+	}
+
+	if _, err := table.AddRow(map[string]interface{}{"f": "1.5"}); err == nil {
+		t.Error("expected an error adding a string to a float64 column")
+	}
+}
+
+func TestSetColumnType(t *testing.T) {
+	table := newPriceTable(t, 1, 2)
+	table.Set(0, "s", "AAPL")
+	table.Set(1, "s", 42)
+
+	if err := table.SetColumnType("s", reflect.TypeFor[string]()); err == nil {
+		t.Fatal("expected an error typing a column holding an int as string")
+	}
+	if columnType, _ := table.ColumnType("s"); columnType != nil {
+		t.Errorf("column type after a failed SetColumnType = %v, want untyped", columnType)
+	}
+	for i, want := range []interface{}{"AAPL", 42} {
+		if value, _ := table.Get(i, "s"); value != want {
+			t.Errorf("row %d s = %v after a failed SetColumnType, want %v", i, value, want)
+		}
+	}
+
+	table.Set(1, "s", nil)
+	if err := table.SetColumnType("s", reflect.TypeFor[string]()); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Set(1, "s", 42); err == nil {
+		t.Error("expected an error setting an int on a string column")
+	}
+
+	if err := table.SetColumnType("f", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Set(0, "f", "untyped"); err != nil {
+		t.Errorf("untyped column rejected a string: %v", err)
+	}
+	if value, _ := table.Get(1, "f"); value != 2.0 {
+		t.Errorf("f = %v after untyping, want 2", value)
+	}
+
+	if err := table.SetColumnType("missing", nil); err == nil {
+		t.Error("expected an error for a missing column")
+	}
+}
+
+func columnOf(t *testing.T, table *Table, column string) []interface{} {
+	t.Helper()
+	values, ok := table.GetColumnValues(column)
+	if !ok {
+		t.Fatalf("column %s not found", column)
+	}
+	return values
+}
+
+func TestSortBy(t *testing.T) {
+	table := newPriceTable(t)
+	rows := []struct {
+		f interface{}
+		s string
+	}{
+		{2.0, "a"},
+		{nil, "b"},
+		{1.0, "c"},
+		{math.NaN(), "d"},
+		{2.0, "e"},
+		{nil, "f"},
+		{1.0, "g"},
+	}
+	for _, row := range rows {
+		table.AddRow(map[string]interface{}{"f": row.f, "s": row.s})
+	}
+
+	sorted, err := table.SortBy("f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// equal keys keep their order, unset and NaN go last in their order
+	want := []interface{}{"c", "g", "a", "e", "b", "d", "f"}
+	if got := columnOf(t, sorted, "s"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted s = %v, want %v", got, want)
+	}
+	if columnType, _ := sorted.ColumnType("f"); columnType != reflect.TypeFor[float64]() {
+		t.Errorf("sorted column type = %v, want float64", columnType)
+	}
+	if got := columnOf(t, table, "s"); got[0] != "a" {
+		t.Error("SortBy should not reorder the original table")
+	}
+
+	if _, err := table.SortBy("missing"); err == nil {
+		t.Error("expected an error sorting by a missing column")
+	}
+}
+
+func TestSortByMixedNumbers(t *testing.T) {
+	table := NewTable([]string{"n"})
+	for _, value := range []interface{}{2, 1.5, int64(-3), float32(0.5), uint(1)} {
+		table.AddRow(map[string]interface{}{"n": value})
+	}
+
+	sorted, err := table.SortBy("n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{int64(-3), float32(0.5), uint(1), 1.5, 2}
+	if got := columnOf(t, sorted, "n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted n = %v, want %v", got, want)
+	}
+}
+
+func TestSortByIncomparable(t *testing.T) {
+	table := NewTable([]string{"v"})
+	table.AddRow(map[string]interface{}{"v": "a"})
+	table.AddRow(map[string]interface{}{"v": 1})
+	if _, err := table.SortBy("v"); err == nil {
+		t.Error("expected an error sorting a string against an int")
+	}
+
+	structs := NewTable([]string{"v"})
+	structs.AddRow(map[string]interface{}{"v": struct{}{}})
+	structs.AddRow(map[string]interface{}{"v": struct{}{}})
+	if _, err := structs.SortBy("v"); err == nil {
+		t.Error("expected an error sorting structs")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	table := newPriceTable(t, 1, 2)
+	table.Set(0, "s", "AAPL")
+
+	selected, err := table.Select("s", "f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(selected.Cols(), []string{"s", "f"}) || selected.NumRows() != 2 {
+		t.Fatalf("selected %v with %d rows", selected.Cols(), selected.NumRows())
+	}
+	if value, _ := selected.Get(0, "s"); value != "AAPL" {
+		t.Errorf("selected s = %v, want AAPL", value)
+	}
+	if columnType, _ := selected.ColumnType("f"); columnType != reflect.TypeFor[float64]() {
+		t.Errorf("selected column type = %v, want float64", columnType)
+	}
+
+	if _, err := table.Select("f", "missing"); err == nil {
+		t.Error("expected an error selecting a missing column")
+	}
+	if _, err := table.Select("f", "s", "f"); err == nil {
+		t.Error("expected an error selecting a column twice")
+	}
+}
+
+func TestMap(t *testing.T) {
+	table := newPriceTable(t, 1, 2)
+
+	doubled, err := table.Map(func(row map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"f": row["f"].(float64) * 2}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := columnOf(t, doubled, "f"); !reflect.DeepEqual(got, []interface{}{2.0, 4.0}) {
+		t.Errorf("mapped f = %v, want [2 4]", got)
+	}
+
+	_, err = table.Map(func(row map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"f": "not a float"}
+	})
+	if err == nil {
+		t.Error("expected an error for a result of the wrong type")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	table := newPriceTable(t, 1, 2, 3)
+
+	filtered := table.Filter(func(row map[string]interface{}) bool {
+		return row["f"].(float64) >= 2
+	})
+	if got := columnOf(t, filtered, "f"); !reflect.DeepEqual(got, []interface{}{2.0, 3.0}) {
+		t.Errorf("filtered f = %v, want [2 3]", got)
+	}
+	if columnType, _ := filtered.ColumnType("f"); columnType != reflect.TypeFor[float64]() {
+		t.Errorf("filtered column type = %v, want float64", columnType)
+	}
+	if err := filtered.Set(0, "f", "not a float"); err == nil {
+		t.Error("filtered table should still type check its columns")
+	}
+}
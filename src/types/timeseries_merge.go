@@ -0,0 +1,140 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// JoinType decides which timestamps Merge keeps.
+type JoinType int
+
+const (
+	// InnerJoin keeps timestamps present in both tables.
+	InnerJoin JoinType = iota
+	// LeftJoin keeps the timestamps of the left table, the one Merge is called on.
+	LeftJoin
+	// OuterJoin keeps timestamps present in either table.
+	OuterJoin
+)
+
+// Suffixes appended by Merge to column names present in both tables.
+const (
+	MergeLeftSuffix  = "_left"
+	MergeRightSuffix = "_right"
+)
+
+// Merge joins t and other on timestamp into a new table. Columns present in
+// both tables are renamed with MergeLeftSuffix and MergeRightSuffix. Cells with
+// no matching row on one side are left unset.
+func (t *TimeseriesTable[T]) Merge(other *TimeseriesTable[T], how JoinType) (*TimeseriesTable[T], error) {
+	return merge[T](t, other, how)
+}
+
+// MergeTimeseries joins two tables with different value types, e.g. candles
+// and fundamentals, the same way as Merge. The merged values keep their
+// original types.
+func MergeTimeseries[L, R any](left *TimeseriesTable[L], right *TimeseriesTable[R], how JoinType) (*TimeseriesTable[interface{}], error) {
+	return merge[interface{}](left, right, how)
+}
+
+/* HELPER FUNCTIONS */
+// merge joins left and right into a table of M, which is either the value type
+// of both tables or interface{}.
+func merge[M, L, R any](left *TimeseriesTable[L], right *TimeseriesTable[R], how JoinType) (*TimeseriesTable[M], error) {
+	leftColumns, rightColumns, err := mergeColumns(left.Cols(), right.Cols())
+	if err != nil {
+		return nil, err
+	}
+
+	left.sortTimestamps()
+	right.sortTimestamps()
+	merged := NewTimeseriesTable[M](append(append([]string(nil), leftColumns...), rightColumns...))
+
+	addRow := func(timestamp time.Time, inLeft, inRight bool) error {
+		row := make(map[string]M)
+		if inLeft {
+			copyMergedValues(row, left, timestamp, leftColumns)
+		}
+		if inRight {
+			copyMergedValues(row, right, timestamp, rightColumns)
+		}
+		return merged.AddRow(timestamp, row)
+	}
+
+	leftTimestamps, rightTimestamps := left.timestampArr, right.timestampArr
+	i, j := 0, 0
+	for i < len(leftTimestamps) || j < len(rightTimestamps) {
+		switch {
+		case j == len(rightTimestamps) || (i < len(leftTimestamps) && leftTimestamps[i].Before(rightTimestamps[j])):
+			if how != InnerJoin {
+				err = addRow(leftTimestamps[i], true, false)
+			}
+			i++
+		case i == len(leftTimestamps) || rightTimestamps[j].Before(leftTimestamps[i]):
+			if how == OuterJoin {
+				err = addRow(rightTimestamps[j], false, true)
+			}
+			j++
+		default:
+			err = addRow(leftTimestamps[i], true, true)
+			i++
+			j++
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return merged, nil
+}
+
+// copyMergedValues copies the values set in table at timestamp into row,
+// under the merged column names.
+func copyMergedValues[M, V any](row map[string]M, table *TimeseriesTable[V], timestamp time.Time, columns []string) {
+	values, _ := table.GetRow(timestamp)
+	for i, column := range table.Cols() {
+		if value, ok := values[column]; ok {
+			row[columns[i]] = interface{}(value).(M) // M is V or interface{}
+		}
+	}
+}
+
+// mergeColumns returns the column names used for the left and right tables in
+// a merge, suffixing the ones present in both.
+func mergeColumns(left, right []string) ([]string, []string, error) {
+	inLeft := make(map[string]bool, len(left))
+	for _, column := range left {
+		inLeft[column] = true
+	}
+	inRight := make(map[string]bool, len(right))
+	for _, column := range right {
+		inRight[column] = true
+	}
+
+	seen := make(map[string]bool, len(left)+len(right))
+	rename := func(columns []string, conflicts map[string]bool, suffix string) ([]string, error) {
+		renamed := make([]string, len(columns))
+		for i, column := range columns {
+			renamed[i] = column
+			if conflicts[column] {
+				renamed[i] = column + suffix
+			}
+			if seen[renamed[i]] {
+				return nil, fmt.Errorf("column %s appears twice after merging", renamed[i])
+			}
+			seen[renamed[i]] = true
+		}
+		return renamed, nil
+	}
+
+	leftColumns, err := rename(left, inRight, MergeLeftSuffix)
+	if err != nil {
+		return nil, nil, err
+	}
+	rightColumns, err := rename(right, inLeft, MergeRightSuffix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return leftColumns, rightColumns, nil
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	left := NewTimeseriesTable[float64]([]string{"close", "volume"})
+	left.AddRow(start, map[string]float64{"close": 1, "volume": 10})
+	left.AddRow(start.Add(time.Minute), map[string]float64{"close": 2})
+
+	// same instant as left's second row, expressed in another location
+	right := NewTimeseriesTable[float64]([]string{"close", "pe"})
+	right.AddRow(start.Add(time.Minute).In(time.FixedZone("IST", 19800)), map[string]float64{"close": 20, "pe": 5})
+	right.AddRow(start.Add(2*time.Minute), map[string]float64{"pe": 6})
+
+	tests := []struct {
+		how  JoinType
+		want []time.Time
+	}{
+		{InnerJoin, []time.Time{start.Add(time.Minute)}},
+		{LeftJoin, []time.Time{start, start.Add(time.Minute)}},
+		{OuterJoin, []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute)}},
+	}
+	for _, test := range tests {
+		merged, err := left.Merge(right, test.how)
+		if err != nil {
+			t.Fatalf("join %d: %v", test.how, err)
+		}
+
+		wantColumns := []string{"close_left", "volume", "close_right", "pe"}
+		if !reflect.DeepEqual(merged.Cols(), wantColumns) {
+			t.Errorf("join %d: columns = %v, want %v", test.how, merged.Cols(), wantColumns)
+		}
+
+		rows := merged.Rows()
+		if len(rows) != len(test.want) {
+			t.Fatalf("join %d: got %d rows, want %d", test.how, len(rows), len(test.want))
+		}
+		for i, row := range rows {
+			if !row.Timestamp.Equal(test.want[i]) {
+				t.Errorf("join %d: row %d at %s, want %s", test.how, i, row.Timestamp, test.want[i])
+			}
+		}
+
+		matched, _ := merged.GetRow(start.Add(time.Minute))
+		wantRow := map[string]float64{"close_left": 2, "close_right": 20, "pe": 5}
+		if !reflect.DeepEqual(matched, wantRow) {
+			t.Errorf("join %d: matched row = %v, want %v", test.how, matched, wantRow)
+		}
+	}
+
+	outer, _ := left.Merge(right, OuterJoin)
+	if row, _ := outer.GetRow(start.Add(2 * time.Minute)); !reflect.DeepEqual(row, map[string]float64{"pe": 6}) {
+		t.Errorf("right-only row = %v, want only pe", row)
+	}
+}
+
+func TestMergeColumnConflicts(t *testing.T) {
+	left := NewTimeseriesTable[float64]([]string{"close", "close_right"})
+	right := NewTimeseriesTable[float64]([]string{"close"})
+	if _, err := left.Merge(right, InnerJoin); err == nil {
+		t.Error("expected an error when a suffixed name collides with an existing column")
+	}
+}
+
+func TestMergeEmpty(t *testing.T) {
+	left := NewTimeseriesTable[float64]([]string{"a"})
+	right := NewTimeseriesTable[float64]([]string{"b"})
+	right.AddRow(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), map[string]float64{"b": 1})
+
+	for _, how := range []JoinType{InnerJoin, LeftJoin} {
+		merged, err := left.Merge(right, how)
+		if err != nil || merged.NumRows() != 0 {
+			t.Errorf("join %d with empty left: %d rows, %v", how, merged.NumRows(), err)
+		}
+	}
+	merged, err := left.Merge(right, OuterJoin)
+	if err != nil || merged.NumRows() != 1 {
+		t.Errorf("outer join with empty left: %v rows, %v", merged.NumRows(), err)
+	}
+}
+
+func TestMergeTimeseriesDifferentTypes(t *testing.T) {
+	type candle struct {
+		Open, Close float64
+	}
+
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	candles := NewTimeseriesTable[candle]([]string{"AAPL", "close"})
+	candles.AddRow(start.Add(time.Minute), map[string]candle{"AAPL": {3, 4}})
+	candles.AddRow(start, map[string]candle{"AAPL": {1, 2}})
+
+	fundamentals := NewTimeseriesTable[float64]([]string{"pe", "close"})
+	fundamentals.AddRow(start, map[string]float64{"pe": 25, "close": 2})
+
+	merged, err := MergeTimeseries(candles, fundamentals, LeftJoin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantColumns := []string{"AAPL", "close_left", "pe", "close_right"}
+	if !reflect.DeepEqual(merged.Cols(), wantColumns) {
+		t.Errorf("columns = %v, want %v", merged.Cols(), wantColumns)
+	}
+	if merged.NumRows() != 2 {
+		t.Fatalf("got %d rows, want 2", merged.NumRows())
+	}
+
+	row, _ := merged.GetRow(start)
+	wantRow := map[string]interface{}{"AAPL": candle{1, 2}, "pe": 25.0, "close_right": 2.0}
+	if !reflect.DeepEqual(row, wantRow) {
+		t.Errorf("first row = %v, want %v", row, wantRow)
+	}
+	if value, ok := merged.GetValue(start.Add(time.Minute), "AAPL"); !ok || value != (candle{3, 4}) {
+		t.Errorf("AAPL at second row = %v, %v", value, ok)
+	}
+	if _, ok := merged.GetValue(start.Add(time.Minute), "pe"); ok {
+		t.Error("pe has no matching row and should be unset")
+	}
+
+	inner, err := MergeTimeseries(candles, fundamentals, InnerJoin)
+	if err != nil || inner.NumRows() != 1 {
+		t.Errorf("inner join: %v rows, %v", inner.NumRows(), err)
+	}
+}
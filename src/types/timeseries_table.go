@@ -6,31 +6,81 @@ import (
 	"time"
 )
 
+// DuplicatePolicy decides what AddRow does when a row for the timestamp
+// already exists, e.g. the repeated local hour when DST ends.
+//
+// Local times skipped when DST starts do not exist, time.Date resolves them
+// to the same instant as the local time one gap earlier. A feed that still
+// emits bars in the skipped hour therefore duplicates the bars before it:
+// DuplicateMerge overwrites them and DuplicateDrop ignores the new bar, so
+// check the parsed hour against the feed's if that matters. DuplicateError
+// and DuplicateShift reject them.
+type DuplicatePolicy int
+
+const (
+	// DuplicateError rejects the row with an error.
+	DuplicateError DuplicatePolicy = iota
+	// DuplicateMerge writes the new values over the existing row.
+	DuplicateMerge
+	// DuplicateShift resolves the new row to the other instant with the same
+	// wall clock time in the table's location, i.e. the second occurrence of
+	// a repeated DST hour. Duplicates outside a DST overlap are an error.
+	DuplicateShift
+	// DuplicateDrop keeps the existing row and ignores the new one.
+	DuplicateDrop
+)
+
 type TimeseriesTable[T any] struct {
-	table        *Table
-	timestampMap map[time.Time]int
-	timestampArr []time.Time
-	isDirty      bool
+	table           *Table
+	timestampMap    map[time.Time]int
+	timestampArr    []time.Time
+	isDirty         bool // timestampArr has rows out of order, see sortTimestamps
+	duplicatePolicy DuplicatePolicy
+	location        *time.Location
 }
 
 func NewTimeseriesTable[T any](columns []string) *TimeseriesTable[T] {
+	table := NewTable(columns)
+	for i := range table.data {
+		table.data[i] = newTypedColumn[T]()
+	}
+
 	return &TimeseriesTable[T]{
-		table:        NewTable(columns),
+		table:        table,
 		timestampMap: make(map[time.Time]int),
-		timestampArr: make([]time.Time, len(columns)),
+		timestampArr: make([]time.Time, 0),
 		isDirty:      false,
+
+		duplicatePolicy: DuplicateError,
+		location:        nil,
+	}
+}
+
+// SetDuplicatePolicy sets how AddRow handles duplicate timestamps. location is
+// the zone the data's local times were parsed in and is required by
+// DuplicateShift, the other policies ignore it.
+func (t *TimeseriesTable[T]) SetDuplicatePolicy(policy DuplicatePolicy, location *time.Location) error {
+	if policy < DuplicateError || policy > DuplicateDrop {
+		return fmt.Errorf("unknown duplicate policy %d", policy)
+	}
+
+	if policy == DuplicateShift && location == nil {
+		return fmt.Errorf("duplicate policy shift requires a location")
 	}
+
+	t.duplicatePolicy = policy
+	t.location = location
+	return nil
 }
 
 func (t *TimeseriesTable[T]) CreateRow(timestamp time.Time) error {
-	if _, ok := t.timestampMap[timestamp]; ok {
+	if _, ok := t.timestampMap[timestampKey(timestamp)]; ok {
 		return fmt.Errorf("timestamp %s already exists, failed creating new row", timestamp)
 	}
 
 	index := t.table.NewRow()
-	t.timestampMap[timestamp] = index
-	t.timestampArr = append(t.timestampArr, timestamp)
-	t.isDirty = true
+	t.timestampMap[timestampKey(timestamp)] = index
+	t.insertTimestamp(timestamp)
 
 	return nil
 }
@@ -51,7 +101,27 @@ func (t *TimeseriesTable[T]) SetRow(timestamp time.Time, row map[string]T) error
 	return nil
 }
 
+// AddRow creates a row for timestamp and sets its values. Duplicate
+// timestamps are handled according to the table's DuplicatePolicy.
 func (t *TimeseriesTable[T]) AddRow(timestamp time.Time, row map[string]T) error {
+	if _, ok := t.GetIndexFor(timestamp); ok {
+		switch t.duplicatePolicy {
+		case DuplicateDrop:
+			return nil
+		case DuplicateMerge:
+			return t.SetRow(timestamp, row)
+		case DuplicateShift:
+			shifted, ok := dstCounterpart(timestamp, t.location)
+			if !ok && beforeDSTGap(timestamp, t.location) {
+				return fmt.Errorf("timestamp %s already exists, it may be a local time skipped when DST starts in %s", timestamp, t.location)
+			}
+			if !ok {
+				return fmt.Errorf("timestamp %s already exists and is not in a DST overlap of %s", timestamp, t.location)
+			}
+			timestamp = shifted
+		}
+	}
+
 	err := t.CreateRow(timestamp)
 	if err != nil {
 		return err
@@ -65,6 +135,8 @@ func (t *TimeseriesTable[T]) AddRow(timestamp time.Time, row map[string]T) error
 	return nil
 }
 
+// GetRow returns the values set for timestamp. Columns that were never set
+// are left out of the map rather than reported as zero values.
 func (t TimeseriesTable[T]) GetRow(timestamp time.Time) (map[string]T, bool) {
 	index, ok := t.GetIndexFor(timestamp)
 	if !ok {
@@ -73,15 +145,43 @@ func (t TimeseriesTable[T]) GetRow(timestamp time.Time) (map[string]T, bool) {
 		interfaceMap, _ := t.table.GetRow(index) // ignoring ok as GetIndexFor is already checked
 		typedMap := make(map[string]T)
 		for key, value := range interfaceMap {
-			typedValue, _ := value.(T) // ignoring type assertion error as setting of values is type checked
+			typedValue, ok := value.(T) // only fails for unset values, setting of values is type checked
+			if !ok {
+				continue
+			}
 			typedMap[key] = typedValue
 		}
 		return typedMap, true
 	}
 }
 
+// TimestampAsOf returns the latest timestamp at or before timestamp.
+func (t *TimeseriesTable[T]) TimestampAsOf(timestamp time.Time) (time.Time, bool) {
+	t.sortTimestamps()
+	return asOf(t.timestampArr, timestamp)
+}
+
+// GetRowAsOf returns the latest row at or before timestamp, for sources that
+// are not perfectly aligned. Columns unset in that row are left out, use
+// GetValueAsOf to search further back for them.
+func (t *TimeseriesTable[T]) GetRowAsOf(timestamp time.Time) (map[string]T, bool) {
+	asOfTimestamp, ok := t.TimestampAsOf(timestamp)
+	if !ok {
+		return nil, false
+	}
+
+	return t.GetRow(asOfTimestamp)
+}
+
+// GetValueAsOf returns column from the latest row at or before timestamp that
+// has it set, so an instrument's missing bar reads as its previous one.
+func (t *TimeseriesTable[T]) GetValueAsOf(timestamp time.Time, column string) (T, bool) {
+	t.sortTimestamps()
+	return t.valueAsOf(t.timestampArr, timestamp, column)
+}
+
 func (t TimeseriesTable[T]) GetIndexFor(timestamp time.Time) (int, bool) {
-	index, ok := t.timestampMap[timestamp]
+	index, ok := t.timestampMap[timestampKey(timestamp)]
 	if !ok {
 		return -1, false
 	}
@@ -100,7 +200,11 @@ func (t TimeseriesTable[T]) GetValue(timestamp time.Time, column string) (T, boo
 			var zero T
 			return zero, false
 		}
-		assertedValue, _ := value.(T) // ignoring type assertion error as setting of values is type checked
+		assertedValue, ok := value.(T) // only fails for unset values, setting of values is type checked
+		if !ok {
+			var zero T
+			return zero, false
+		}
 		return assertedValue, true
 	}
 }
@@ -111,17 +215,11 @@ func (t *TimeseriesTable[T]) SetValue(timestamp time.Time, column string, value
 		return fmt.Errorf("timestamp %s not found", timestamp)
 	}
 
-	t.table.Set(index, column, value)
-	return nil
+	return t.table.Set(index, column, value)
 }
 
 func (t *TimeseriesTable[T]) Iterator() <-chan map[string]T {
-	if t.isDirty {
-		sort.Slice(t.timestampArr, func(i, j int) bool {
-			return t.timestampArr[i].Before(t.timestampArr[j])
-		})
-		t.isDirty = false
-	}
+	t.sortTimestamps()
 
 	ch := make(chan map[string]T)
 	go func() {
@@ -135,12 +233,7 @@ func (t *TimeseriesTable[T]) Iterator() <-chan map[string]T {
 }
 
 func (t *TimeseriesTable[T]) Rows() []TimeseriesRow[T] {
-	if t.isDirty {
-		sort.Slice(t.timestampArr, func(i, j int) bool {
-			return t.timestampArr[i].Before(t.timestampArr[j])
-		})
-		t.isDirty = false
-	}
+	t.sortTimestamps()
 
 	rows := make([]TimeseriesRow[T], len(t.timestampArr))
 	for i, timestamp := range t.timestampArr {
@@ -156,6 +249,165 @@ func (t TimeseriesTable[T]) Cols() []string {
 	return t.table.Cols()
 }
 
+// ColumnValues returns the values of column in timestamp order, unset cells
+// are zero values.
+func (t *TimeseriesTable[T]) ColumnValues(column string) ([]T, bool) {
+	t.sortTimestamps()
+
+	values, ok := ColumnValues[T](t.table, column)
+	if !ok {
+		return nil, false
+	}
+
+	ordered := make([]T, len(t.timestampArr))
+	for i, timestamp := range t.timestampArr {
+		ordered[i] = values[t.timestampMap[timestampKey(timestamp)]]
+	}
+	return ordered, true
+}
+
+func (t TimeseriesTable[T]) NumRows() int {
+	return len(t.timestampArr)
+}
+
+// Between returns a view of the rows with start <= timestamp <= end.
+func (t *TimeseriesTable[T]) Between(start, end time.Time) *TimeseriesView[T] {
+	t.sortTimestamps()
+	i, j := betweenBounds(t.timestampArr, start, end)
+	return t.newView(i, j)
+}
+
+// Before returns a view of the last n rows strictly before timestamp, or all
+// of them if n <= 0.
+func (t *TimeseriesTable[T]) Before(timestamp time.Time, n int) *TimeseriesView[T] {
+	t.sortTimestamps()
+	i, j := beforeBounds(t.timestampArr, timestamp, n)
+	return t.newView(i, j)
+}
+
+// After returns a view of the first n rows strictly after timestamp, or all
+// of them if n <= 0.
+func (t *TimeseriesTable[T]) After(timestamp time.Time, n int) *TimeseriesView[T] {
+	t.sortTimestamps()
+	i, j := afterBounds(t.timestampArr, timestamp, n)
+	return t.newView(i, j)
+}
+
+// Slice returns a view of the rows at sorted positions [i, j).
+func (t *TimeseriesTable[T]) Slice(i, j int) (*TimeseriesView[T], error) {
+	t.sortTimestamps()
+	if i < 0 || j > len(t.timestampArr) || i > j {
+		return nil, fmt.Errorf("slice [%d:%d] out of range for %d rows", i, j, len(t.timestampArr))
+	}
+
+	return t.newView(i, j), nil
+}
+
+/* HELPER FUNCTIONS */
+// insertTimestamp appends timestamp and marks timestampArr dirty if it is out
+// of order. Data usually arrives in order and then never needs sorting.
+func (t *TimeseriesTable[T]) insertTimestamp(timestamp time.Time) {
+	if n := len(t.timestampArr); n > 0 && !t.timestampArr[n-1].Before(timestamp) {
+		t.isDirty = true
+	}
+	t.timestampArr = append(t.timestampArr, timestamp)
+}
+
+// sortTimestamps sorts timestampArr once after out of order inserts, so that
+// loading newest-first data costs one sort instead of a shift per row. Every
+// method reading timestampArr in order calls it first.
+func (t *TimeseriesTable[T]) sortTimestamps() {
+	if !t.isDirty {
+		return
+	}
+
+	sort.Slice(t.timestampArr, func(i, j int) bool {
+		return t.timestampArr[i].Before(t.timestampArr[j])
+	})
+	t.isDirty = false
+}
+
+// dstCounterpart returns the other instant that has the same wall clock time
+// as timestamp in location, if timestamp falls in a DST overlap (the hour
+// repeated when clocks go back).
+func dstCounterpart(timestamp time.Time, location *time.Location) (time.Time, bool) {
+	local := timestamp.In(location)
+	_, offset := local.Zone()
+	start, end := local.ZoneBounds()
+
+	// timestamp is the first occurrence, the second one is after the transition
+	if !end.IsZero() {
+		_, nextOffset := end.In(location).Zone()
+		if nextOffset < offset {
+			counterpart := timestamp.Add(time.Duration(offset-nextOffset) * time.Second)
+			if !counterpart.Before(end) {
+				return counterpart, true
+			}
+		}
+	}
+
+	// timestamp is the second occurrence, the first one is before the transition
+	if !start.IsZero() {
+		_, previousOffset := start.Add(-time.Second).In(location).Zone()
+		if previousOffset > offset {
+			counterpart := timestamp.Add(-time.Duration(previousOffset-offset) * time.Second)
+			if counterpart.Before(start) {
+				return counterpart, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// beforeDSTGap reports whether timestamp falls within one gap length before
+// clocks go forward in location. time.Date resolves the skipped local times
+// to these instants.
+func beforeDSTGap(timestamp time.Time, location *time.Location) bool {
+	local := timestamp.In(location)
+	_, offset := local.Zone()
+	_, end := local.ZoneBounds()
+	if end.IsZero() {
+		return false
+	}
+
+	_, nextOffset := end.In(location).Zone()
+	gap := time.Duration(nextOffset-offset) * time.Second
+	return gap > 0 && end.Sub(timestamp) <= gap
+}
+
+// valueAsOf searches the sorted timestamps back from timestamp for the latest
+// row with column set.
+func (t TimeseriesTable[T]) valueAsOf(timestamps []time.Time, timestamp time.Time, column string) (T, bool) {
+	if _, ok := t.table.columnMap[column]; ok {
+		for i := asOfIndex(timestamps, timestamp) - 1; i >= 0; i-- {
+			if value, ok := t.GetValue(timestamps[i], column); ok {
+				return value, true
+			}
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// newView copies the timestamps at sorted positions [i, j) so the view is not
+// affected by rows added to the table later. Row values are not copied.
+func (t *TimeseriesTable[T]) newView(i, j int) *TimeseriesView[T] {
+	timestamps := make([]time.Time, j-i)
+	copy(timestamps, t.timestampArr[i:j])
+	return &TimeseriesView[T]{
+		table:      t,
+		timestamps: timestamps,
+	}
+}
+
+// timestampKey normalises timestamp so the same instant maps to the same row
+// regardless of its location or monotonic clock reading.
+func timestampKey(timestamp time.Time) time.Time {
+	return timestamp.Round(0).UTC()
+}
+
 type TimeseriesRow[T any] struct {
 	Timestamp time.Time
 	table     *TimeseriesTable[T]
@@ -0,0 +1,291 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return location
+}
+
+func TestAddRowDuplicateShiftFallBack(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	table := NewTimeseriesTable[float64]([]string{"close"})
+	if err := table.SetDuplicatePolicy(DuplicateShift, newYork); err != nil {
+		t.Fatal(err)
+	}
+
+	// naive local bars on the day clocks go back, 01:00 appears twice
+	hours := []int{0, 1, 1, 2, 3, 4}
+	for i, hour := range hours {
+		timestamp := time.Date(2024, 11, 3, hour, 0, 0, 0, newYork)
+		if err := table.AddRow(timestamp, map[string]float64{"close": float64(i)}); err != nil {
+			t.Fatalf("bar %d: %v", i, err)
+		}
+	}
+
+	want := []time.Time{
+		time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC), // 00:00 EDT
+		time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC), // 01:00 EDT
+		time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC), // 01:00 EST
+		time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC), // 02:00 EST
+		time.Date(2024, 11, 3, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 11, 3, 9, 0, 0, 0, time.UTC),
+	}
+	rows := table.Rows()
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if !row.Timestamp.Equal(want[i]) {
+			t.Errorf("row %d at %s, want %s", i, row.Timestamp.UTC(), want[i])
+		}
+		if value, _ := row.GetValue("close"); value != float64(i) {
+			t.Errorf("row %d close = %v, want %v", i, value, i)
+		}
+	}
+}
+
+func TestAddRowDuplicateShiftOutsideOverlap(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	table := NewTimeseriesTable[float64]([]string{"close"})
+	if err := table.SetDuplicatePolicy(DuplicateShift, newYork); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := time.Date(2024, 6, 3, 10, 0, 0, 0, newYork)
+	if err := table.AddRow(timestamp, map[string]float64{"close": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.AddRow(timestamp, map[string]float64{"close": 2}); err == nil {
+		t.Fatal("expected an error for a duplicate outside a DST overlap")
+	}
+	if table.NumRows() != 1 {
+		t.Fatalf("got %d rows, want 1", table.NumRows())
+	}
+}
+
+func TestAddRowSpringForwardGap(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	// a feed still emitting a 02:00 bar on the day clocks go forward
+	bars := func(table *TimeseriesTable[float64]) error {
+		for _, hour := range []int{0, 1, 2, 3} {
+			timestamp := time.Date(2024, 3, 10, hour, 0, 0, 0, newYork)
+			if err := table.AddRow(timestamp, map[string]float64{"close": float64(hour)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	oneAM := time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC)
+
+	shift := NewTimeseriesTable[float64]([]string{"close"})
+	shift.SetDuplicatePolicy(DuplicateShift, newYork)
+	if err := bars(shift); err == nil {
+		t.Error("expected DuplicateShift to reject the skipped 02:00 bar")
+	}
+	if value, _ := shift.GetValue(oneAM, "close"); value != 1 {
+		t.Errorf("01:00 close = %v, want 1", value)
+	}
+
+	// the documented hazard: 02:00 resolves to 01:00 EST and overwrites it
+	merge := NewTimeseriesTable[float64]([]string{"close"})
+	merge.SetDuplicatePolicy(DuplicateMerge, nil)
+	if err := bars(merge); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := merge.GetValue(oneAM, "close"); value != 2 || merge.NumRows() != 3 {
+		t.Errorf("01:00 close = %v with %d rows, want 2 with 3 rows", value, merge.NumRows())
+	}
+}
+
+func TestAddRowDuplicatePolicies(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		policy DuplicatePolicy
+		want   float64
+	}{
+		{DuplicateMerge, 2},
+		{DuplicateDrop, 1},
+	}
+
+	for _, test := range tests {
+		table := NewTimeseriesTable[float64]([]string{"close"})
+		if err := table.SetDuplicatePolicy(test.policy, nil); err != nil {
+			t.Fatal(err)
+		}
+		table.AddRow(timestamp, map[string]float64{"close": 1})
+		// same instant in another location is the same row
+		if err := table.AddRow(timestamp.In(time.FixedZone("IST", 19800)), map[string]float64{"close": 2}); err != nil {
+			t.Fatalf("policy %d: %v", test.policy, err)
+		}
+		if value, _ := table.GetValue(timestamp, "close"); value != test.want || table.NumRows() != 1 {
+			t.Errorf("policy %d: close = %v with %d rows, want %v with 1 row", test.policy, value, table.NumRows(), test.want)
+		}
+	}
+
+	table := NewTimeseriesTable[float64]([]string{"close"})
+	table.AddRow(timestamp, map[string]float64{"close": 1})
+	if err := table.AddRow(timestamp, map[string]float64{"close": 2}); err == nil {
+		t.Error("expected an error with the default policy")
+	}
+}
+
+func TestSetDuplicatePolicyValidation(t *testing.T) {
+	table := NewTimeseriesTable[float64]([]string{"close"})
+	if err := table.SetDuplicatePolicy(DuplicatePolicy(42), nil); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+	if err := table.SetDuplicatePolicy(DuplicateShift, nil); err == nil {
+		t.Error("expected an error for DuplicateShift without a location")
+	}
+}
+
+func TestDSTCounterpart(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	firstOne := time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC)  // 01:00 EDT
+	secondOne := time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC) // 01:00 EST
+
+	if counterpart, ok := dstCounterpart(firstOne, newYork); !ok || !counterpart.Equal(secondOne) {
+		t.Errorf("counterpart of first 01:00 = %s, %v", counterpart, ok)
+	}
+	if counterpart, ok := dstCounterpart(secondOne, newYork); !ok || !counterpart.Equal(firstOne) {
+		t.Errorf("counterpart of second 01:00 = %s, %v", counterpart, ok)
+	}
+	if _, ok := dstCounterpart(time.Date(2024, 11, 3, 4, 30, 0, 0, time.UTC), newYork); ok {
+		t.Error("00:30 EDT is not in the overlap")
+	}
+	if _, ok := dstCounterpart(time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC), newYork); ok {
+		t.Error("spring forward has no overlap")
+	}
+}
+
+func TestBeforeDSTGap(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	tests := []struct {
+		timestamp time.Time
+		want      bool
+	}{
+		{time.Date(2024, 3, 10, 5, 59, 0, 0, time.UTC), false}, // 00:59 EST
+		{time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), true},   // 01:00 EST
+		{time.Date(2024, 3, 10, 6, 59, 0, 0, time.UTC), true},  // 01:59 EST
+		{time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC), false},  // 03:00 EDT
+		{time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), false}, // fall back
+	}
+	for _, test := range tests {
+		if got := beforeDSTGap(test.timestamp, newYork); got != test.want {
+			t.Errorf("beforeDSTGap(%s) = %v, want %v", test.timestamp, got, test.want)
+		}
+	}
+}
+
+func TestCreateRowKeepsTimestampsSorted(t *testing.T) {
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	table := NewTimeseriesTable[float64]([]string{"close"})
+
+	// in order, out of order, at the front and between existing rows
+	for _, minute := range []int{2, 3, 5, 0, 4, 1, 6} {
+		timestamp := start.Add(time.Duration(minute) * time.Minute)
+		if err := table.AddRow(timestamp, map[string]float64{"close": float64(minute)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows := table.Rows()
+	if len(rows) != 7 {
+		t.Fatalf("got %d rows, want 7", len(rows))
+	}
+	for i, row := range rows {
+		if want := start.Add(time.Duration(i) * time.Minute); !row.Timestamp.Equal(want) {
+			t.Errorf("row %d at %s, want %s", i, row.Timestamp, want)
+		}
+		if value, _ := row.GetValue("close"); value != float64(i) {
+			t.Errorf("row %d close = %v, want %d", i, value, i)
+		}
+	}
+
+	i := 0
+	for row := range table.Iterator() {
+		if row["close"] != float64(i) {
+			t.Errorf("iterator row %d close = %v", i, row["close"])
+		}
+		i++
+	}
+
+	values, _ := table.ColumnValues("close")
+	for i, value := range values {
+		if value != float64(i) {
+			t.Errorf("ColumnValues[%d] = %v, want %d", i, value, i)
+		}
+	}
+}
+
+func TestTimeseriesTableUnsetValues(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	table := NewTimeseriesTable[float64]([]string{"close", "volume"})
+	table.AddRow(timestamp, map[string]float64{"close": 0})
+
+	if value, ok := table.GetValue(timestamp, "close"); !ok || value != 0 {
+		t.Errorf("close = %v, %v, want a set zero", value, ok)
+	}
+	if _, ok := table.GetValue(timestamp, "volume"); ok {
+		t.Error("unset volume should not be found")
+	}
+
+	row, ok := table.GetRow(timestamp)
+	if !ok || len(row) != 1 || row["close"] != 0 {
+		t.Errorf("GetRow = %v, %v, want only close", row, ok)
+	}
+	if _, ok := row["volume"]; ok {
+		t.Error("unset volume should be left out of the row")
+	}
+
+	if err := table.SetValue(timestamp, "missing", 1); err == nil {
+		t.Error("expected an error setting a missing column")
+	}
+}
+
+func TestAddRowReverseOrder(t *testing.T) {
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	table := NewTimeseriesTable[float64]([]string{"close"})
+	for i := 0; i < 3; i++ {
+		table.AddRow(start.Add(time.Duration(i)*time.Minute), map[string]float64{"close": float64(i)})
+	}
+	if table.isDirty {
+		t.Error("in order rows should not need sorting")
+	}
+
+	// newest first, as many candle APIs return them
+	for i := 1000; i >= 3; i-- {
+		table.AddRow(start.Add(time.Duration(i)*time.Minute), map[string]float64{"close": float64(i)})
+	}
+	values, _ := table.ColumnValues("close")
+	if len(values) != 1001 {
+		t.Fatalf("got %d values, want 1001", len(values))
+	}
+	for i, value := range values {
+		if value != float64(i) {
+			t.Fatalf("ColumnValues[%d] = %v, want %d", i, value, i)
+		}
+	}
+	if table.isDirty {
+		t.Error("reading the table should have sorted it")
+	}
+}
+
+func BenchmarkAddRowReverseOrder(b *testing.B) {
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	for n := 0; n < b.N; n++ {
+		table := NewTimeseriesTable[float64]([]string{"close"})
+		for i := 100000; i > 0; i-- {
+			table.AddRow(start.Add(time.Duration(i)*time.Minute), map[string]float64{"close": float64(i)})
+		}
+		table.Rows()
+	}
+}
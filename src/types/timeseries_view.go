@@ -0,0 +1,179 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TimeseriesView is a sorted, read-only subset of a TimeseriesTable's rows.
+// It only holds timestamps, values are read from the underlying table.
+type TimeseriesView[T any] struct {
+	table      *TimeseriesTable[T]
+	timestamps []time.Time
+}
+
+func (v TimeseriesView[T]) NumRows() int {
+	return len(v.timestamps)
+}
+
+func (v TimeseriesView[T]) Cols() []string {
+	return v.table.Cols()
+}
+
+func (v TimeseriesView[T]) Timestamps() []time.Time {
+	timestamps := make([]time.Time, len(v.timestamps))
+	copy(timestamps, v.timestamps)
+	return timestamps
+}
+
+func (v TimeseriesView[T]) Rows() []TimeseriesRow[T] {
+	rows := make([]TimeseriesRow[T], len(v.timestamps))
+	for i, timestamp := range v.timestamps {
+		rows[i] = TimeseriesRow[T]{
+			Timestamp: timestamp,
+			table:     v.table,
+		}
+	}
+	return rows
+}
+
+func (v TimeseriesView[T]) Iterator() <-chan map[string]T {
+	ch := make(chan map[string]T)
+	go func() {
+		for _, timestamp := range v.timestamps {
+			row, _ := v.table.GetRow(timestamp)
+			ch <- row
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+func (v TimeseriesView[T]) GetRow(timestamp time.Time) (map[string]T, bool) {
+	if !v.contains(timestamp) {
+		return nil, false
+	}
+
+	return v.table.GetRow(timestamp)
+}
+
+func (v TimeseriesView[T]) GetValue(timestamp time.Time, column string) (T, bool) {
+	if !v.contains(timestamp) {
+		var zero T
+		return zero, false
+	}
+
+	return v.table.GetValue(timestamp, column)
+}
+
+func (v TimeseriesView[T]) TimestampAsOf(timestamp time.Time) (time.Time, bool) {
+	return asOf(v.timestamps, timestamp)
+}
+
+func (v TimeseriesView[T]) GetRowAsOf(timestamp time.Time) (map[string]T, bool) {
+	asOfTimestamp, ok := v.TimestampAsOf(timestamp)
+	if !ok {
+		return nil, false
+	}
+
+	return v.table.GetRow(asOfTimestamp)
+}
+
+// GetValueAsOf only searches back through the rows of the view.
+func (v TimeseriesView[T]) GetValueAsOf(timestamp time.Time, column string) (T, bool) {
+	return v.table.valueAsOf(v.timestamps, timestamp, column)
+}
+
+func (v TimeseriesView[T]) Between(start, end time.Time) *TimeseriesView[T] {
+	i, j := betweenBounds(v.timestamps, start, end)
+	return v.subView(i, j)
+}
+
+func (v TimeseriesView[T]) Before(timestamp time.Time, n int) *TimeseriesView[T] {
+	i, j := beforeBounds(v.timestamps, timestamp, n)
+	return v.subView(i, j)
+}
+
+func (v TimeseriesView[T]) After(timestamp time.Time, n int) *TimeseriesView[T] {
+	i, j := afterBounds(v.timestamps, timestamp, n)
+	return v.subView(i, j)
+}
+
+func (v TimeseriesView[T]) Slice(i, j int) (*TimeseriesView[T], error) {
+	if i < 0 || j > len(v.timestamps) || i > j {
+		return nil, fmt.Errorf("slice [%d:%d] out of range for %d rows", i, j, len(v.timestamps))
+	}
+
+	return v.subView(i, j), nil
+}
+
+/* HELPER FUNCTIONS */
+// subView shares the timestamps slice, it is never written to after creation.
+func (v TimeseriesView[T]) subView(i, j int) *TimeseriesView[T] {
+	return &TimeseriesView[T]{
+		table:      v.table,
+		timestamps: v.timestamps[i:j:j],
+	}
+}
+
+func (v TimeseriesView[T]) contains(timestamp time.Time) bool {
+	i := sort.Search(len(v.timestamps), func(k int) bool {
+		return !v.timestamps[k].Before(timestamp)
+	})
+	return i < len(v.timestamps) && v.timestamps[i].Equal(timestamp)
+}
+
+// asOf returns the latest of the sorted timestamps at or before timestamp.
+func asOf(timestamps []time.Time, timestamp time.Time) (time.Time, bool) {
+	i := asOfIndex(timestamps, timestamp)
+	if i == 0 {
+		return time.Time{}, false
+	}
+	return timestamps[i-1], true
+}
+
+// asOfIndex returns the number of sorted timestamps at or before timestamp.
+func asOfIndex(timestamps []time.Time, timestamp time.Time) int {
+	return sort.Search(len(timestamps), func(k int) bool {
+		return timestamps[k].After(timestamp)
+	})
+}
+
+// The bounds helpers below expect timestamps to be sorted and return the
+// [i, j) positions of the matching rows.
+
+func betweenBounds(timestamps []time.Time, start, end time.Time) (int, int) {
+	i := sort.Search(len(timestamps), func(k int) bool {
+		return !timestamps[k].Before(start)
+	})
+	j := sort.Search(len(timestamps), func(k int) bool {
+		return timestamps[k].After(end)
+	})
+	if j < i {
+		j = i
+	}
+	return i, j
+}
+
+func beforeBounds(timestamps []time.Time, timestamp time.Time, n int) (int, int) {
+	j := sort.Search(len(timestamps), func(k int) bool {
+		return !timestamps[k].Before(timestamp)
+	})
+	i := 0
+	if n > 0 && j-n > 0 {
+		i = j - n
+	}
+	return i, j
+}
+
+func afterBounds(timestamps []time.Time, timestamp time.Time, n int) (int, int) {
+	i := sort.Search(len(timestamps), func(k int) bool {
+		return timestamps[k].After(timestamp)
+	})
+	j := len(timestamps)
+	if n > 0 && i+n < j {
+		j = i + n
+	}
+	return i, j
+}
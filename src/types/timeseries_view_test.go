@@ -0,0 +1,185 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+var viewStart = time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+
+func minute(n int) time.Time {
+	return viewStart.Add(time.Duration(n) * time.Minute)
+}
+
+// newMinuteTable has rows at minutes 0, 2, 4, ... 2*(n-1) with close equal to
+// the minute.
+func newMinuteTable(t *testing.T, n int) *TimeseriesTable[float64] {
+	t.Helper()
+	table := NewTimeseriesTable[float64]([]string{"close", "volume"})
+	for i := n - 1; i >= 0; i-- {
+		if err := table.AddRow(minute(2*i), map[string]float64{"close": float64(2 * i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return table
+}
+
+func viewMinutes(view *TimeseriesView[float64]) []int {
+	minutes := make([]int, 0, view.NumRows())
+	for _, timestamp := range view.Timestamps() {
+		minutes = append(minutes, int(timestamp.Sub(viewStart)/time.Minute))
+	}
+	return minutes
+}
+
+func assertMinutes(t *testing.T, name string, got []int, want ...int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+	}
+}
+
+func TestTimeseriesTableRanges(t *testing.T) {
+	table := newMinuteTable(t, 5) // minutes 0, 2, 4, 6, 8
+
+	assertMinutes(t, "Between(2, 6)", viewMinutes(table.Between(minute(2), minute(6))), 2, 4, 6)
+	assertMinutes(t, "Between(3, 5)", viewMinutes(table.Between(minute(3), minute(5))), 4)
+	assertMinutes(t, "Between(6, 2)", viewMinutes(table.Between(minute(6), minute(2))))
+	assertMinutes(t, "Before(6, 2)", viewMinutes(table.Before(minute(6), 2)), 2, 4)
+	assertMinutes(t, "Before(6, 0)", viewMinutes(table.Before(minute(6), 0)), 0, 2, 4)
+	assertMinutes(t, "Before(0, 3)", viewMinutes(table.Before(minute(0), 3)))
+	assertMinutes(t, "After(2, 2)", viewMinutes(table.After(minute(2), 2)), 4, 6)
+	assertMinutes(t, "After(3, 0)", viewMinutes(table.After(minute(3), 0)), 4, 6, 8)
+
+	slice, err := table.Slice(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMinutes(t, "Slice(1, 3)", viewMinutes(slice), 2, 4)
+
+	for _, bounds := range [][2]int{{-1, 2}, {2, 6}, {3, 2}} {
+		if _, err := table.Slice(bounds[0], bounds[1]); err == nil {
+			t.Errorf("Slice(%d, %d) should fail", bounds[0], bounds[1])
+		}
+	}
+}
+
+func TestTimeseriesViewIsStable(t *testing.T) {
+	table := newMinuteTable(t, 3) // minutes 0, 2, 4
+	view := table.Between(minute(0), minute(4))
+
+	// inserting in the middle shifts the table's timestamps in place
+	table.AddRow(minute(1), map[string]float64{"close": 1})
+	table.AddRow(minute(-1), map[string]float64{"close": -1})
+	assertMinutes(t, "view after inserts", viewMinutes(view), 0, 2, 4)
+
+	if _, ok := view.GetRow(minute(1)); ok {
+		t.Error("view should not contain rows added after it was created")
+	}
+	if value, ok := view.GetValue(minute(2), "close"); !ok || value != 2 {
+		t.Errorf("view close at 2 = %v, %v", value, ok)
+	}
+	if _, ok := view.GetValue(minute(2), "volume"); ok {
+		t.Error("unset volume should not be found")
+	}
+}
+
+func TestTimeseriesViewNarrowing(t *testing.T) {
+	table := newMinuteTable(t, 6) // minutes 0 ... 10
+	view := table.Between(minute(2), minute(8))
+
+	assertMinutes(t, "view.Before(8, 2)", viewMinutes(view.Before(minute(8), 2)), 4, 6)
+	assertMinutes(t, "view.After(0, 1)", viewMinutes(view.After(minute(0), 1)), 2)
+	assertMinutes(t, "view.Between(0, 5)", viewMinutes(view.Between(minute(0), minute(5))), 2, 4)
+
+	slice, err := view.Slice(1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMinutes(t, "view.Slice(1, 4)", viewMinutes(slice), 4, 6, 8)
+	if _, err := view.Slice(0, 5); err == nil {
+		t.Error("view.Slice(0, 5) should fail on a 4 row view")
+	}
+
+	closes := make([]float64, 0)
+	for row := range view.Iterator() {
+		closes = append(closes, row["close"])
+	}
+	if len(closes) != 4 || closes[0] != 2 || closes[3] != 8 {
+		t.Errorf("iterated closes = %v, want [2 4 6 8]", closes)
+	}
+}
+
+func TestTimeseriesTableAsOf(t *testing.T) {
+	table := newMinuteTable(t, 3) // minutes 0, 2, 4
+	table.SetValue(minute(2), "volume", 100)
+
+	if _, ok := table.GetRowAsOf(minute(-1)); ok {
+		t.Error("nothing should be found before the first row")
+	}
+
+	tests := []struct {
+		at, want int
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 2},
+		{3, 2},
+		{100, 4},
+	}
+	for _, test := range tests {
+		timestamp, ok := table.TimestampAsOf(minute(test.at))
+		if !ok || !timestamp.Equal(minute(test.want)) {
+			t.Errorf("TimestampAsOf(%d) = %s, %v, want minute %d", test.at, timestamp, ok, test.want)
+		}
+		if value, ok := table.GetValueAsOf(minute(test.at), "close"); !ok || value != float64(test.want) {
+			t.Errorf("GetValueAsOf(%d) = %v, %v, want %d", test.at, value, ok, test.want)
+		}
+	}
+
+	if row, ok := table.GetRowAsOf(minute(3)); !ok || row["volume"] != 100 {
+		t.Errorf("GetRowAsOf(3) = %v, %v", row, ok)
+	}
+	// the latest row has volume unset, GetValueAsOf searches back for it
+	if value, ok := table.GetValueAsOf(minute(5), "volume"); !ok || value != 100 {
+		t.Errorf("GetValueAsOf(5, volume) = %v, %v, want 100 from minute 2", value, ok)
+	}
+	if row, _ := table.GetRowAsOf(minute(5)); row["volume"] != 0 || len(row) != 1 {
+		t.Errorf("GetRowAsOf(5) = %v, want only close", row)
+	}
+	if _, ok := table.GetValueAsOf(minute(1), "volume"); ok {
+		t.Error("volume is not set at or before minute 1")
+	}
+	if _, ok := table.GetValueAsOf(minute(5), "missing"); ok {
+		t.Error("a missing column should not be found")
+	}
+}
+
+func TestTimeseriesViewAsOf(t *testing.T) {
+	table := newMinuteTable(t, 4) // minutes 0, 2, 4, 6
+	view := table.After(minute(1), 2)
+
+	if _, ok := view.GetRowAsOf(minute(1)); ok {
+		t.Error("rows before the view should not be found")
+	}
+	if value, ok := view.GetValueAsOf(minute(100), "close"); !ok || value != 4 {
+		t.Errorf("view GetValueAsOf(100) = %v, %v, want 4", value, ok)
+	}
+
+	// volume is only set before the view
+	table.SetValue(minute(0), "volume", 100)
+	if _, ok := view.GetValueAsOf(minute(100), "volume"); ok {
+		t.Error("view GetValueAsOf should not search before the view")
+	}
+	table.SetValue(minute(2), "volume", 200)
+	if value, ok := view.GetValueAsOf(minute(100), "volume"); !ok || value != 200 {
+		t.Errorf("view GetValueAsOf(100, volume) = %v, %v, want 200", value, ok)
+	}
+}